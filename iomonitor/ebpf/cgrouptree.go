@@ -0,0 +1,34 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// discoverChildCgroups recursively walks root and returns the path of every
+// child cgroup beneath it (root itself is not included). Containers commonly
+// have per-thread children such as init.scope that also need to be
+// registered for filtering alongside the container's own cgroup.
+func discoverChildCgroups(root string) ([]string, error) {
+	var children []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if path == root || !d.IsDir() {
+			return nil
+		}
+		children = append(children, path)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return children, nil
+}
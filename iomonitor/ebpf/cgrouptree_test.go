@@ -0,0 +1,97 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDiscoverChildCgroups(t *testing.T) {
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "init.scope"))
+	mustMkdir(t, filepath.Join(root, "sub", "grandchild"))
+
+	children, err := discoverChildCgroups(root)
+	if err != nil {
+		t.Fatalf("discoverChildCgroups: %v", err)
+	}
+	sort.Strings(children)
+
+	want := []string{
+		filepath.Join(root, "init.scope"),
+		filepath.Join(root, "sub"),
+		filepath.Join(root, "sub", "grandchild"),
+	}
+	sort.Strings(want)
+	if len(children) != len(want) {
+		t.Fatalf("children = %v, want %v", children, want)
+	}
+	for i := range want {
+		if children[i] != want[i] {
+			t.Fatalf("children = %v, want %v", children, want)
+		}
+	}
+}
+
+func TestDiscoverChildCgroups_Empty(t *testing.T) {
+	root := t.TempDir()
+	children, err := discoverChildCgroups(root)
+	if err != nil {
+		t.Fatalf("discoverChildCgroups: %v", err)
+	}
+	if len(children) != 0 {
+		t.Fatalf("expected no children, got %v", children)
+	}
+}
+
+func mustMkdir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		t.Fatalf("mkdir %s: %v", path, err)
+	}
+}
+
+func TestCgroupTreeWatcher_NewChild(t *testing.T) {
+	root := t.TempDir()
+
+	w, err := newCgroupTreeWatcher()
+	if err != nil {
+		t.Fatalf("newCgroupTreeWatcher: %v", err)
+	}
+	defer w.Close()
+
+	if err := w.watch(root); err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	seen := make(chan string, 1)
+	go func() {
+		_ = w.run(ctx, func(child string) {
+			select {
+			case seen <- child:
+			default:
+			}
+		})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	newDir := filepath.Join(root, "new.scope")
+	mustMkdir(t, newDir)
+
+	select {
+	case got := <-seen:
+		if got != newDir {
+			t.Fatalf("observed child = %q, want %q", got, newDir)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for new child cgroup to be observed")
+	}
+}
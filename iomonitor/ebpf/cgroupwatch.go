@@ -0,0 +1,112 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// cgroupTreeWatcher watches a cgroup directory tree for newly created child
+// cgroups (e.g. a container's init.scope appearing after start) and reports
+// them to a callback. Newly observed subdirectories are watched in turn so
+// grandchildren are picked up too.
+type cgroupTreeWatcher struct {
+	fd      int
+	wdPaths map[int]string
+}
+
+func newCgroupTreeWatcher() (*cgroupTreeWatcher, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify_init1: %w", err)
+	}
+	return &cgroupTreeWatcher{fd: fd, wdPaths: make(map[int]string)}, nil
+}
+
+// Close releases the underlying inotify file descriptor.
+func (w *cgroupTreeWatcher) Close() error {
+	return unix.Close(w.fd)
+}
+
+// watch adds an inotify watch for dir and, recursively, every existing
+// child directory beneath it.
+func (w *cgroupTreeWatcher) watch(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		return w.watchDir(path)
+	})
+}
+
+func (w *cgroupTreeWatcher) watchDir(path string) error {
+	wd, err := unix.InotifyAddWatch(w.fd, path, unix.IN_CREATE|unix.IN_ISDIR|unix.IN_MOVED_TO)
+	if err != nil {
+		return fmt.Errorf("inotify_add_watch %s: %w", path, err)
+	}
+	w.wdPaths[wd] = path
+	return nil
+}
+
+// run blocks reading inotify events until ctx is canceled, invoking onNew
+// for every newly created child cgroup directory.
+func (w *cgroupTreeWatcher) run(ctx context.Context, onNew func(string)) error {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		n, err := unix.Read(w.fd, buf)
+		if err != nil {
+			if err == unix.EAGAIN || err == unix.EINTR {
+				time.Sleep(50 * time.Millisecond)
+				continue
+			}
+			return fmt.Errorf("read inotify events: %w", err)
+		}
+
+		offset := 0
+		for offset+unix.SizeofInotifyEvent <= n {
+			raw := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameLen := int(raw.Len)
+			name := ""
+			if nameLen > 0 {
+				nameBytes := buf[offset+unix.SizeofInotifyEvent : offset+unix.SizeofInotifyEvent+nameLen]
+				name = strings.TrimRight(string(nameBytes), "\x00")
+			}
+			offset += unix.SizeofInotifyEvent + nameLen
+
+			if raw.Mask&unix.IN_ISDIR == 0 || name == "" {
+				continue
+			}
+			parent, ok := w.wdPaths[int(raw.Wd)]
+			if !ok {
+				continue
+			}
+			child := filepath.Join(parent, name)
+			if err := w.watchDir(child); err != nil {
+				// The child may already have been removed; nothing to watch.
+				continue
+			}
+			onNew(child)
+		}
+	}
+}
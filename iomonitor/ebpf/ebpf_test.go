@@ -97,14 +97,12 @@ func TestCgroupFilter(t *testing.T) {
 	}
 	defer exitTP.Close()
 
-	targetID, err := cgroupInode(targetCgroup)
+	loader := NewLoader(objs)
+	targetID, err := loader.AddTarget(targetCgroup)
 	if err != nil {
-		t.Fatalf("stat target cgroup: %v", err)
-	}
-	key := uint32(0)
-	if err := objs.ConfigMap.Put(key, targetID); err != nil {
-		t.Fatalf("set config cgroup id: %v", err)
+		t.Fatalf("register target cgroup: %v", err)
 	}
+	defer func() { _ = loader.RemoveTarget(targetID) }()
 
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
@@ -201,14 +199,6 @@ func currentCgroupPath(mount string) (string, error) {
 	return "", errors.New("cgroup v2 path not found in /proc/self/cgroup")
 }
 
-func cgroupInode(path string) (uint64, error) {
-	var st unix.Stat_t
-	if err := unix.Stat(path, &st); err != nil {
-		return 0, err
-	}
-	return st.Ino, nil
-}
-
 func moveToCgroup(path string) error {
 	procs := filepath.Join(path, "cgroup.procs")
 	pid := strconv.Itoa(os.Getpid())
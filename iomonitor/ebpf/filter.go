@@ -16,13 +16,14 @@ import (
 // ErrProcessNotFound is returned when no process with the given comm name is found.
 var ErrProcessNotFound = errors.New("process not found")
 
-// resolveTargetCgroupPath returns the absolute cgroup v2 directory path to use
+// resolveTargetCgroupPath returns the absolute cgroup directory path to use
 // as the eBPF cgroup filter target.
 //
 // If cfgPath is non-empty it is used directly (after validation).
-// Otherwise the function locates the banyand process via /proc and returns its
-// container-level cgroup path so that bpf_get_current_cgroup_id() in the
-// kernel will produce an exact match.
+// Otherwise the function locates the banyand process via /proc and resolves
+// its container-level cgroup path with cgroupPathForPID, which is also what
+// CommSelector uses for auto-detect targets; see its doc comment for the
+// cgroup v1/hybrid/v2 hierarchy handling.
 func resolveTargetCgroupPath(cfgPath string) (string, error) {
 	if cfgPath != "" {
 		return resolveCgroupPath(cfgPath)
@@ -34,17 +35,11 @@ func resolveTargetCgroupPath(cfgPath string) (string, error) {
 		return "", fmt.Errorf("failed to find banyand process: %w", findErr)
 	}
 
-	cgRel, readErr := readCgroupV2Path(targetPID)
-	if readErr != nil {
-		return "", fmt.Errorf("failed to read cgroup for banyand (pid %d): %w", targetPID, readErr)
-	}
-
-	cgMount, mountErr := findCgroup2Mount()
-	if mountErr != nil {
-		return "", fmt.Errorf("failed to find cgroup2 mount: %w", mountErr)
+	path, err := cgroupPathForPID(targetPID)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve cgroup for banyand (pid %d): %w", targetPID, err)
 	}
-
-	return filepath.Join(cgMount, cgRel), nil
+	return path, nil
 }
 
 // resolveCgroupPath validates that the given path exists and looks like a
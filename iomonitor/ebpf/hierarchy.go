@@ -0,0 +1,235 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CgroupMode identifies which cgroup hierarchy layout is active on the host.
+type CgroupMode int
+
+const (
+	// CgroupModeUnified means the host mounts a single cgroup2 filesystem
+	// and nothing else (bpf_get_current_cgroup_id() works directly).
+	CgroupModeUnified CgroupMode = iota
+	// CgroupModeHybrid means both a cgroup2 mount and one or more legacy
+	// per-controller cgroup v1 mounts are present (the systemd default on
+	// many distros before cgroup v2 became the default).
+	CgroupModeHybrid
+	// CgroupModeLegacy means only per-controller cgroup v1 mounts exist;
+	// there is no cgroup2 mount at all.
+	CgroupModeLegacy
+)
+
+func (m CgroupMode) String() string {
+	switch m {
+	case CgroupModeUnified:
+		return "unified"
+	case CgroupModeHybrid:
+		return "hybrid"
+	case CgroupModeLegacy:
+		return "legacy"
+	default:
+		return fmt.Sprintf("CgroupMode(%d)", int(m))
+	}
+}
+
+// v1Mount describes a single cgroup v1 per-controller mount parsed from
+// /proc/self/mountinfo.
+type v1Mount struct {
+	mountPoint  string
+	controllers map[string]bool
+}
+
+// DetectCgroupMode inspects /proc/self/mountinfo to determine whether the
+// host uses a pure cgroup v2 unified hierarchy, a hybrid setup (cgroup2 plus
+// legacy per-controller mounts), or pure cgroup v1 (legacy). This matters
+// because bpf_get_current_cgroup_id() only reflects the unified hierarchy,
+// so on a legacy host there is no cgroup id the filter can match (see
+// ErrV1FilteringUnsupported).
+func DetectCgroupMode() (CgroupMode, error) {
+	haveUnified, v1Mounts, err := parseCgroupMountInfo("/proc/self/mountinfo")
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case haveUnified && len(v1Mounts) > 0:
+		return CgroupModeHybrid, nil
+	case haveUnified:
+		return CgroupModeUnified, nil
+	case len(v1Mounts) > 0:
+		return CgroupModeLegacy, nil
+	default:
+		return 0, errors.New("no cgroup mounts found in /proc/self/mountinfo")
+	}
+}
+
+// parseCgroupMountInfo walks a mountinfo file (see proc(5)) and reports
+// whether a cgroup2 mount is present plus the set of cgroup v1 per-controller
+// mounts it finds.
+func parseCgroupMountInfo(path string) (bool, []v1Mount, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, nil, err
+	}
+	defer f.Close()
+
+	var haveUnified bool
+	var v1Mounts []v1Mount
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fsType, mountPoint, superOpts, ok := parseMountInfoLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		switch fsType {
+		case "cgroup2":
+			haveUnified = true
+		case "cgroup":
+			v1Mounts = append(v1Mounts, v1Mount{
+				mountPoint:  mountPoint,
+				controllers: parseCgroupSuperOptions(superOpts),
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, nil, err
+	}
+	return haveUnified, v1Mounts, nil
+}
+
+// parseMountInfoLine splits a single mountinfo line into the filesystem
+// type, mount point, and super block options (the three fields after the
+// "-" separator documented in proc(5)). ok is false if the line is malformed.
+func parseMountInfoLine(line string) (fsType, mountPoint, superOpts string, ok bool) {
+	fields := strings.Fields(line)
+	sepIdx := -1
+	for i, f := range fields {
+		if f == "-" {
+			sepIdx = i
+			break
+		}
+	}
+	// Fields before the separator: id parent-id major:minor root mount-point
+	// options [optional-fields...]. mount-point is always index 4.
+	if sepIdx < 0 || len(fields) < sepIdx+4 || len(fields) <= 4 {
+		return "", "", "", false
+	}
+	fsType = fields[sepIdx+1]
+	superOpts = fields[sepIdx+3]
+	mountPoint = fields[4]
+	return fsType, mountPoint, superOpts, true
+}
+
+// parseCgroupSuperOptions turns a comma-separated cgroup v1 super option
+// list (e.g. "rw,nosuid,cpu,cpuacct") into the set of controller names it
+// contains, ignoring generic mount flags and the "name=" option.
+func parseCgroupSuperOptions(opts string) map[string]bool {
+	controllers := make(map[string]bool)
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "" || isGenericMountFlag(opt) || strings.HasPrefix(opt, "name=") {
+			continue
+		}
+		controllers[opt] = true
+	}
+	return controllers
+}
+
+func isGenericMountFlag(opt string) bool {
+	switch opt {
+	case "rw", "ro", "nosuid", "nodev", "noexec", "relatime", "noatime":
+		return true
+	default:
+		return false
+	}
+}
+
+// findCgroupV1Mount locates the cgroup v1 mount point that manages the given
+// controller (e.g. "pids" or "memory").
+func findCgroupV1Mount(controller string) (string, error) {
+	_, v1Mounts, err := parseCgroupMountInfo("/proc/self/mountinfo")
+	if err != nil {
+		return "", err
+	}
+	for _, m := range v1Mounts {
+		if m.controllers[controller] {
+			return m.mountPoint, nil
+		}
+	}
+	return "", fmt.Errorf("no cgroup v1 mount found for controller %q", controller)
+}
+
+// readCgroupV1Path reads /proc/<pid>/cgroup and returns the relative path
+// for the hierarchy that manages the given controller.
+func readCgroupV1Path(pid int, controller string) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, c := range strings.Split(parts[1], ",") {
+			if c == controller {
+				return parts[2], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("controller %q not found in /proc/%d/cgroup", controller, pid)
+}
+
+// preferredV1Controllers lists the controllers resolveTargetCgroupPathV1
+// tries, in order, when only cgroup v1 hierarchies are available. pids and
+// memory are almost always mounted.
+var preferredV1Controllers = []string{"pids", "memory"}
+
+// ErrV1FilteringUnsupported explains why resolveTargetCgroupPathV1 never
+// returns a usable path: bpf_get_current_cgroup_id() only reflects the
+// unified (cgroup v2) hierarchy, so a v1 per-controller directory path can
+// never match what the kernel program computes. Making v1/hybrid hosts
+// filterable requires the kernel program to attach by cgroup fd instead of
+// matching on cgroup id -- a kernel-side (.c) change not present in this
+// build. Resolving the path anyway and registering it as if it were a v2
+// cgroup id would silently filter nothing.
+var ErrV1FilteringUnsupported = errors.New("cgroup v1/hybrid filtering requires a kernel program that attaches by cgroup fd, which this build does not have")
+
+// resolveTargetCgroupPathV1 locates the cgroup v1 (or hybrid) directory path
+// for targetPID, trying each controller in preferredV1Controllers until one
+// resolves, and never returns a path: once a controller resolves it fails
+// with ErrV1FilteringUnsupported (see its doc comment for why the resolved
+// path can't be used for filtering); if none resolves it fails with the
+// last resolution error instead.
+func resolveTargetCgroupPathV1(targetPID int) (string, error) {
+	var lastErr error
+	for _, controller := range preferredV1Controllers {
+		mount, err := findCgroupV1Mount(controller)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		rel, err := readCgroupV1Path(targetPID, controller)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		path := filepath.Join(mount, rel)
+		return "", fmt.Errorf("%w (resolved path would have been %s)", ErrV1FilteringUnsupported, path)
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no usable cgroup v1 controller among %v", preferredV1Controllers)
+	}
+	return "", fmt.Errorf("failed to resolve cgroup v1 path: %w", lastErr)
+}
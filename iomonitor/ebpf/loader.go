@@ -0,0 +1,221 @@
+//go:build ebpf && linux
+
+package ebpf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/SkyAPM/ktm-ebpf/iomonitor/ebpf/generated"
+	ciliumebpf "github.com/cilium/ebpf"
+)
+
+// TargetMeta is the value stored in ConfigMap alongside each tracked cgroup
+// id. It is currently just an enable bit, but leaves room for a per-target
+// label or sampling rate without another map redesign.
+type TargetMeta struct {
+	Enabled uint8
+}
+
+// TargetInfo describes one cgroup currently registered for filtering.
+type TargetInfo struct {
+	ID   uint64
+	Path string
+}
+
+// targetMap is the subset of ConfigMap's operations Loader needs. It exists
+// so tests can substitute a fake in place of the real BPF_MAP_TYPE_HASH,
+// which otherwise requires a loaded kernel program to exercise.
+// *ciliumebpf.Map satisfies it.
+type targetMap interface {
+	Put(key, value interface{}) error
+	Delete(key interface{}) error
+}
+
+// Loader owns a loaded set of iomonitor eBPF objects and the set of cgroup
+// ids currently registered in the ConfigMap hash for filtering. Unlike the
+// single-cgroup-id scheme it replaces, ConfigMap is a BPF_MAP_TYPE_HASH
+// keyed by cgroup id, so any number of targets (and their child cgroups)
+// can be filtered at once.
+type Loader struct {
+	configMap targetMap
+
+	mu       sync.Mutex
+	targets  map[uint64]string
+	watchers map[uint64]func()
+}
+
+// NewLoader wraps already-loaded iomonitor eBPF objects for target
+// management.
+func NewLoader(objs generated.IomonitorObjects) *Loader {
+	return newLoader(objs.ConfigMap)
+}
+
+func newLoader(configMap targetMap) *Loader {
+	return &Loader{
+		configMap: configMap,
+		targets:   make(map[uint64]string),
+		watchers:  make(map[uint64]func()),
+	}
+}
+
+// AddTarget registers path, and recursively every existing child cgroup
+// beneath it, in the ConfigMap hash. It also starts a background watch on
+// path so that child cgroups created later (e.g. a container's init.scope
+// appearing after start) are registered automatically; the watch runs
+// until the target is removed with RemoveTarget or the Loader is closed.
+// The returned id is the cgroup id of path itself.
+func (l *Loader) AddTarget(path string) (uint64, error) {
+	id, err := cgroupID(path)
+	if err != nil {
+		return 0, fmt.Errorf("stat cgroup %s: %w", path, err)
+	}
+
+	children, err := discoverChildCgroups(path)
+	if err != nil {
+		return 0, fmt.Errorf("walk children of %s: %w", path, err)
+	}
+
+	l.mu.Lock()
+	if err := l.putTargetLocked(id, path); err != nil {
+		l.mu.Unlock()
+		return 0, err
+	}
+	for _, child := range children {
+		cid, err := cgroupID(child)
+		if err != nil {
+			// The child may have been removed between the walk and the
+			// stat; it is not fatal to the parent registration.
+			continue
+		}
+		if err := l.putTargetLocked(cid, child); err != nil {
+			l.mu.Unlock()
+			return 0, err
+		}
+	}
+	l.mu.Unlock()
+
+	if err := l.watchChildren(id, path); err != nil {
+		return 0, fmt.Errorf("watch children of %s: %w", path, err)
+	}
+	return id, nil
+}
+
+// AddSelectors resolves every selector to a cgroup path (via
+// resolveTargetCgroupPaths) and registers each one with AddTarget. It
+// returns the ids successfully added so far if resolution or registration
+// fails partway through.
+func (l *Loader) AddSelectors(ctx context.Context, selectors []TargetSelector) ([]uint64, error) {
+	paths, err := resolveTargetCgroupPaths(ctx, selectors)
+	if err != nil {
+		return nil, fmt.Errorf("resolve target selectors: %w", err)
+	}
+
+	ids := make([]uint64, 0, len(paths))
+	for _, path := range paths {
+		id, err := l.AddTarget(path)
+		if err != nil {
+			return ids, fmt.Errorf("add target %s: %w", path, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// watchChildren starts a background inotify watch on path so that new
+// children are registered as they appear. Calling it again for a rootID
+// that already has a watch (e.g. a second AddTarget for the same path)
+// cancels the previous one first, so watches never leak.
+func (l *Loader) watchChildren(rootID uint64, path string) error {
+	watcher, err := newCgroupTreeWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.watch(path); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.mu.Lock()
+	if prevCancel, ok := l.watchers[rootID]; ok {
+		prevCancel()
+	}
+	l.watchers[rootID] = cancel
+	l.mu.Unlock()
+
+	go func() {
+		defer watcher.Close()
+		_ = watcher.run(ctx, func(child string) {
+			cid, err := cgroupID(child)
+			if err != nil {
+				return
+			}
+			l.mu.Lock()
+			_ = l.putTargetLocked(cid, child)
+			l.mu.Unlock()
+		})
+	}()
+	return nil
+}
+
+func (l *Loader) putTargetLocked(id uint64, path string) error {
+	if err := l.configMap.Put(id, TargetMeta{Enabled: 1}); err != nil {
+		return fmt.Errorf("put cgroup id %d (%s): %w", id, path, err)
+	}
+	l.targets[id] = path
+	return nil
+}
+
+// RemoveTarget removes id from the ConfigMap hash and stops any child watch
+// started for it by AddTarget. Child cgroups registered under id are not
+// removed automatically; callers that want a full teardown should also call
+// RemoveTarget for the ids returned by ListTargets.
+func (l *Loader) RemoveTarget(id uint64) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.configMap.Delete(id); err != nil && !errors.Is(err, ciliumebpf.ErrKeyNotExist) {
+		return fmt.Errorf("delete cgroup id %d: %w", id, err)
+	}
+	delete(l.targets, id)
+
+	if cancel, ok := l.watchers[id]; ok {
+		cancel()
+		delete(l.watchers, id)
+	}
+	return nil
+}
+
+// ListTargets returns every cgroup currently registered for filtering.
+func (l *Loader) ListTargets() []TargetInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := make([]TargetInfo, 0, len(l.targets))
+	for id, path := range l.targets {
+		out = append(out, TargetInfo{ID: id, Path: path})
+	}
+	return out
+}
+
+// WatchTarget keeps this Loader's filtered cgroup set in sync with
+// selector across the matched process's restarts, using the multi-target
+// ConfigMap hash so the swap from the old cgroup id to the new one is
+// atomic. See Watcher for the transition semantics.
+func (l *Loader) WatchTarget(ctx context.Context, selector TargetSelector) <-chan TargetEvent {
+	return NewWatcher().WatchTarget(ctx, selector, l)
+}
+
+// Close stops all background child watches. It does not unload the
+// underlying eBPF objects; callers remain responsible for objs.Close().
+func (l *Loader) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for id, cancel := range l.watchers {
+		cancel()
+		delete(l.watchers, id)
+	}
+}
@@ -0,0 +1,173 @@
+//go:build ebpf && linux
+
+package ebpf
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTargetMap is an in-memory stand-in for ConfigMap, letting Loader's
+// bookkeeping be exercised without a loaded kernel program.
+type fakeTargetMap struct {
+	mu      sync.Mutex
+	entries map[uint64]TargetMeta
+}
+
+func newFakeTargetMap() *fakeTargetMap {
+	return &fakeTargetMap{entries: make(map[uint64]TargetMeta)}
+}
+
+func (m *fakeTargetMap) Put(key, value interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key.(uint64)] = value.(TargetMeta)
+	return nil
+}
+
+func (m *fakeTargetMap) Delete(key interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key.(uint64))
+	return nil
+}
+
+func (m *fakeTargetMap) has(key uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.entries[key]
+	return ok
+}
+
+func TestLoader_AddTargetRegistersChildren(t *testing.T) {
+	root := t.TempDir()
+	child := filepath.Join(root, "child")
+	if err := os.Mkdir(child, 0o755); err != nil {
+		t.Fatalf("mkdir child: %v", err)
+	}
+
+	m := newFakeTargetMap()
+	l := newLoader(m)
+	defer l.Close()
+
+	rootID, err := l.AddTarget(root)
+	if err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+	childID, err := cgroupID(child)
+	if err != nil {
+		t.Fatalf("cgroupID(child): %v", err)
+	}
+
+	if !m.has(rootID) {
+		t.Error("root id not registered in map")
+	}
+	if !m.has(childID) {
+		t.Error("child id not registered in map")
+	}
+
+	targets := l.ListTargets()
+	if len(targets) != 2 {
+		t.Fatalf("ListTargets() = %v, want 2 entries", targets)
+	}
+}
+
+func TestLoader_RemoveTargetLeavesChildrenRegistered(t *testing.T) {
+	root := t.TempDir()
+	child := filepath.Join(root, "child")
+	if err := os.Mkdir(child, 0o755); err != nil {
+		t.Fatalf("mkdir child: %v", err)
+	}
+
+	m := newFakeTargetMap()
+	l := newLoader(m)
+	defer l.Close()
+
+	rootID, err := l.AddTarget(root)
+	if err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+	childID, err := cgroupID(child)
+	if err != nil {
+		t.Fatalf("cgroupID(child): %v", err)
+	}
+
+	if err := l.RemoveTarget(rootID); err != nil {
+		t.Fatalf("RemoveTarget: %v", err)
+	}
+
+	if m.has(rootID) {
+		t.Error("root id still registered in map after RemoveTarget")
+	}
+	if !m.has(childID) {
+		t.Error("child id should remain registered after RemoveTarget, per documented behavior")
+	}
+
+	targets := l.ListTargets()
+	if len(targets) != 1 || targets[0].ID != childID {
+		t.Fatalf("ListTargets() = %v, want only child id %d", targets, childID)
+	}
+}
+
+func TestLoader_AddTargetTwiceDoesNotLeakWatcher(t *testing.T) {
+	root := t.TempDir()
+
+	m := newFakeTargetMap()
+	l := newLoader(m)
+	defer l.Close()
+
+	id, err := l.AddTarget(root)
+	if err != nil {
+		t.Fatalf("first AddTarget: %v", err)
+	}
+	firstCancel := l.watchers[id]
+
+	if _, err := l.AddTarget(root); err != nil {
+		t.Fatalf("second AddTarget: %v", err)
+	}
+	secondCancel := l.watchers[id]
+
+	if firstCancel == nil || secondCancel == nil {
+		t.Fatal("expected a watcher cancel func to be registered after each AddTarget")
+	}
+
+	// The second AddTarget must have replaced, not stacked, the watcher for
+	// this id; there is no direct way to observe the first goroutine exiting
+	// from outside the package, so this at least pins the one-watcher-per-id
+	// invariant the map itself exposes.
+	if len(l.watchers) != 1 {
+		t.Fatalf("watchers = %v, want exactly one entry for %d", l.watchers, id)
+	}
+}
+
+func TestLoader_WatchChildrenPicksUpNewChild(t *testing.T) {
+	root := t.TempDir()
+
+	m := newFakeTargetMap()
+	l := newLoader(m)
+	defer l.Close()
+
+	if _, err := l.AddTarget(root); err != nil {
+		t.Fatalf("AddTarget: %v", err)
+	}
+
+	child := filepath.Join(root, "late-child")
+	if err := os.Mkdir(child, 0o755); err != nil {
+		t.Fatalf("mkdir child: %v", err)
+	}
+	childID, err := cgroupID(child)
+	if err != nil {
+		t.Fatalf("cgroupID(child): %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !m.has(childID) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !m.has(childID) {
+		t.Fatal("late-created child was never registered by the watch")
+	}
+}
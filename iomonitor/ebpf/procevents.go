@@ -0,0 +1,139 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Kernel process connector constants from linux/cn_proc.h, which x/sys/unix
+// does not expose.
+const (
+	cnIdxProc         = 0x1
+	cnValProc         = 0x1
+	procCnMcastListen = 1
+	procEventExec     = 0x00000002
+	procEventExit     = 0x80000000
+
+	nlmsghdrLen = 16
+	cnMsgLen    = 20
+)
+
+// ProcEvent is a minimal decoding of a kernel process connector
+// notification: which PID changed state, and whether it exited.
+type ProcEvent struct {
+	PID  int
+	Exit bool
+}
+
+// procEventWatcher reads PROC_EVENT_EXEC / PROC_EVENT_EXIT notifications
+// from the kernel's process events connector (netlink NETLINK_CONNECTOR,
+// CN_IDX_PROC). Opening the underlying socket requires CAP_NET_ADMIN; when
+// unavailable, callers should fall back to polling.
+type procEventWatcher struct {
+	fd int
+}
+
+func newProcEventWatcher() (*procEventWatcher, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM, unix.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, fmt.Errorf("open netlink connector socket: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("bind netlink connector socket: %w", err)
+	}
+	w := &procEventWatcher{fd: fd}
+	if err := w.subscribe(); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	return w, nil
+}
+
+// Close releases the underlying netlink socket.
+func (w *procEventWatcher) Close() error {
+	return unix.Close(w.fd)
+}
+
+// subscribe sends the PROC_CN_MCAST_LISTEN control message that subscribes
+// this socket to process events.
+func (w *procEventWatcher) subscribe() error {
+	msg := encodeCnMsg(cnIdxProc, cnValProc, procCnMcastListen)
+	return unix.Sendto(w.fd, msg, 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK})
+}
+
+// run blocks reading process connector notifications until ctx is
+// canceled, decoding PROC_EVENT_EXEC and PROC_EVENT_EXIT events.
+func (w *procEventWatcher) run(ctx context.Context, onEvent func(ProcEvent)) error {
+	buf := make([]byte, 4096)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		n, _, err := unix.Recvfrom(w.fd, buf, 0)
+		if err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return fmt.Errorf("recvfrom netlink connector socket: %w", err)
+		}
+		if ev, ok := decodeProcEvent(buf[:n]); ok {
+			onEvent(ev)
+		}
+	}
+}
+
+// encodeCnMsg builds an nlmsghdr + cn_msg carrying a single uint32 payload,
+// the shape of the PROC_CN_MCAST_LISTEN control message.
+func encodeCnMsg(idx, val, payload uint32) []byte {
+	const dataLen = 4
+	total := nlmsghdrLen + cnMsgLen + dataLen
+	buf := make([]byte, total)
+
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(total)) // nlmsg_len
+	binary.LittleEndian.PutUint16(buf[4:6], unix.NLMSG_DONE)
+	binary.LittleEndian.PutUint16(buf[6:8], 0) // nlmsg_flags
+	binary.LittleEndian.PutUint32(buf[8:12], 0)
+	binary.LittleEndian.PutUint32(buf[12:16], uint32(os.Getpid()))
+
+	off := nlmsghdrLen
+	binary.LittleEndian.PutUint32(buf[off:off+4], idx)
+	binary.LittleEndian.PutUint32(buf[off+4:off+8], val)
+	binary.LittleEndian.PutUint32(buf[off+8:off+12], 0)  // seq
+	binary.LittleEndian.PutUint32(buf[off+12:off+16], 0) // ack
+	binary.LittleEndian.PutUint16(buf[off+16:off+18], uint16(dataLen))
+	binary.LittleEndian.PutUint16(buf[off+18:off+20], 0) // flags
+
+	off += cnMsgLen
+	binary.LittleEndian.PutUint32(buf[off:off+4], payload)
+	return buf
+}
+
+// decodeProcEvent extracts the PID and exec/exit state from a raw process
+// connector notification, skipping the nlmsghdr, cn_msg, and the
+// what/cpu/timestamp_ns prefix common to every proc_event union member.
+func decodeProcEvent(buf []byte) (ProcEvent, bool) {
+	const procEventPrefixLen = 16 // what(4) + cpu(4) + timestamp_ns(8)
+	header := nlmsghdrLen + cnMsgLen
+	if len(buf) < header+procEventPrefixLen+4 {
+		return ProcEvent{}, false
+	}
+	what := binary.LittleEndian.Uint32(buf[header : header+4])
+	pid := binary.LittleEndian.Uint32(buf[header+procEventPrefixLen : header+procEventPrefixLen+4])
+	switch what {
+	case procEventExec:
+		return ProcEvent{PID: int(pid)}, true
+	case procEventExit:
+		return ProcEvent{PID: int(pid), Exit: true}, true
+	default:
+		return ProcEvent{}, false
+	}
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/namespaces"
+)
+
+// containerdInitPID dials the containerd gRPC socket and returns the init
+// PID of the task running the given container in the given namespace.
+func containerdInitPID(ctx context.Context, socket, namespace, containerID string, timeout time.Duration) (int, error) {
+	client, err := containerd.New(socket, containerd.WithTimeout(timeout))
+	if err != nil {
+		return 0, fmt.Errorf("dial containerd socket %s: %w", socket, err)
+	}
+	defer client.Close()
+
+	if namespace == "" {
+		namespace = "default"
+	}
+	nsCtx := namespaces.WithNamespace(ctx, namespace)
+
+	container, err := client.LoadContainer(nsCtx, containerID)
+	if err != nil {
+		return 0, fmt.Errorf("load container %s: %w", containerID, err)
+	}
+	task, err := container.Task(nsCtx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("load task for container %s: %w", containerID, err)
+	}
+	if task.Pid() == 0 {
+		return 0, fmt.Errorf("container %s has no running task", containerID)
+	}
+	return int(task.Pid()), nil
+}
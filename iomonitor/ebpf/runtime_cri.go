@@ -0,0 +1,133 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+)
+
+// criVerboseInfo is the shape of the "info" entry most CRI runtimes
+// (containerd, CRI-O) put in the Info map of a verbose status response.
+type criVerboseInfo struct {
+	Pid int `json:"pid"`
+}
+
+// criContainerInitPID dials the kubelet's CRI socket and resolves the init
+// PID of a pod sandbox, or of a specific container within it.
+func criContainerInitPID(ctx context.Context, socket, namespace, pod, container string, timeout time.Duration) (int, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(dialCtx, "unix://"+socket,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithBlock())
+	if err != nil {
+		return 0, fmt.Errorf("dial CRI socket %s: %w", socket, err)
+	}
+	defer conn.Close()
+
+	client := runtimeapi.NewRuntimeServiceClient(conn)
+
+	sandboxID, err := findPodSandboxID(ctx, client, namespace, pod)
+	if err != nil {
+		return 0, err
+	}
+	if container == "" {
+		return sandboxInitPID(ctx, client, sandboxID)
+	}
+	containerID, err := findContainerID(ctx, client, sandboxID, container)
+	if err != nil {
+		return 0, err
+	}
+	return containerInitPID(ctx, client, containerID)
+}
+
+// findPodSandboxID looks up the sandbox ID for namespace/pod via the
+// standard Kubernetes CRI labels.
+func findPodSandboxID(ctx context.Context, client runtimeapi.RuntimeServiceClient, namespace, pod string) (string, error) {
+	resp, err := client.ListPodSandbox(ctx, &runtimeapi.ListPodSandboxRequest{
+		Filter: &runtimeapi.PodSandboxFilter{
+			LabelSelector: map[string]string{
+				"io.kubernetes.pod.namespace": namespace,
+				"io.kubernetes.pod.name":      pod,
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("list pod sandboxes for %s/%s: %w", namespace, pod, err)
+	}
+	if len(resp.Items) == 0 {
+		return "", fmt.Errorf("no pod sandbox found for %s/%s", namespace, pod)
+	}
+	return resp.Items[0].Id, nil
+}
+
+// findContainerID looks up the container ID within sandboxID matching the
+// standard Kubernetes CRI container-name label.
+func findContainerID(ctx context.Context, client runtimeapi.RuntimeServiceClient, sandboxID, container string) (string, error) {
+	resp, err := client.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{
+			PodSandboxId: sandboxID,
+			LabelSelector: map[string]string{
+				"io.kubernetes.container.name": container,
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("list containers in sandbox %s: %w", sandboxID, err)
+	}
+	if len(resp.Containers) == 0 {
+		return "", fmt.Errorf("no container %q found in sandbox %s", container, sandboxID)
+	}
+	return resp.Containers[0].Id, nil
+}
+
+// sandboxInitPID returns the pause/init PID of a pod sandbox via its
+// verbose status info.
+func sandboxInitPID(ctx context.Context, client runtimeapi.RuntimeServiceClient, sandboxID string) (int, error) {
+	resp, err := client.PodSandboxStatus(ctx, &runtimeapi.PodSandboxStatusRequest{
+		PodSandboxId: sandboxID,
+		Verbose:      true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("pod sandbox status %s: %w", sandboxID, err)
+	}
+	return parseVerbosePID(resp.Info)
+}
+
+// containerInitPID returns the init PID of a container via its verbose
+// status info.
+func containerInitPID(ctx context.Context, client runtimeapi.RuntimeServiceClient, containerID string) (int, error) {
+	resp, err := client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{
+		ContainerId: containerID,
+		Verbose:     true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("container status %s: %w", containerID, err)
+	}
+	return parseVerbosePID(resp.Info)
+}
+
+// parseVerbosePID extracts the "pid" field most CRI runtimes embed as JSON
+// under the "info" key of a verbose status response's Info map.
+func parseVerbosePID(info map[string]string) (int, error) {
+	raw, ok := info["info"]
+	if !ok {
+		return 0, fmt.Errorf("verbose status response has no \"info\" entry")
+	}
+	var parsed criVerboseInfo
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return 0, fmt.Errorf("parse verbose info: %w", err)
+	}
+	if parsed.Pid <= 0 {
+		return 0, fmt.Errorf("verbose info has no usable pid")
+	}
+	return parsed.Pid, nil
+}
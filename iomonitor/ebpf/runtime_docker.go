@@ -0,0 +1,58 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// dockerInspectResponse is the subset of the docker engine API's container
+// inspect response (`GET /containers/<id>/json`) this package needs.
+type dockerInspectResponse struct {
+	State struct {
+		Pid int `json:"Pid"`
+	} `json:"State"`
+}
+
+// dockerInitPID queries the docker daemon over its unix socket for the init
+// PID of the given container.
+func dockerInitPID(ctx context.Context, socket, containerID string, timeout time.Duration) (int, error) {
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(dialCtx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(dialCtx, "unix", socket)
+			},
+		},
+	}
+
+	url := fmt.Sprintf("http://unix/containers/%s/json", containerID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("dial docker socket %s: %w", socket, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("docker inspect %s: unexpected status %s", containerID, resp.Status)
+	}
+
+	var inspect dockerInspectResponse
+	if err := json.NewDecoder(resp.Body).Decode(&inspect); err != nil {
+		return 0, fmt.Errorf("decode docker inspect response for %s: %w", containerID, err)
+	}
+	if inspect.State.Pid <= 0 {
+		return 0, fmt.Errorf("container %s has no running init pid", containerID)
+	}
+	return inspect.State.Pid, nil
+}
@@ -0,0 +1,168 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// TargetSelector resolves the cgroup v2 directory path of the workload that
+// should be filtered by the eBPF program. Implementations translate a
+// user-facing identifier (a process comm name, a container id, a Kubernetes
+// pod reference, ...) into the absolute cgroup path findCgroup2Mount and
+// friends expect.
+type TargetSelector interface {
+	// ResolveCgroupPath returns the absolute cgroup directory path for the
+	// workload this selector describes.
+	ResolveCgroupPath(ctx context.Context) (string, error)
+}
+
+// CommSelector resolves a target by process comm name, matching the
+// pre-existing auto-detect behavior of resolveTargetCgroupPath.
+type CommSelector struct {
+	Comm string
+}
+
+// ResolveCgroupPath implements TargetSelector.
+func (s CommSelector) ResolveCgroupPath(ctx context.Context) (string, error) {
+	pid, err := findPIDByComm(s.Comm)
+	if err != nil {
+		return "", fmt.Errorf("failed to find process by comm %q: %w", s.Comm, err)
+	}
+	return cgroupPathForPID(pid)
+}
+
+// cgroupPathForPID resolves the cgroup directory for an already-known PID,
+// the shared hierarchy-fallback helper behind both CommSelector and
+// resolveTargetCgroupPath's auto-detect mode: on a unified host, and on a
+// hybrid host where the process has a cgroup v2 ("0::") entry, the unified
+// path is returned so that bpf_get_current_cgroup_id() in the kernel
+// produces an exact match. On a legacy (cgroup v1 only) host, or a hybrid
+// host without that entry, it defers to resolveTargetCgroupPathV1 and so
+// returns an error; no per-controller path is returned for filtering.
+func cgroupPathForPID(pid int) (string, error) {
+	mode, err := DetectCgroupMode()
+	if err != nil {
+		return "", fmt.Errorf("failed to detect cgroup hierarchy: %w", err)
+	}
+	if mode == CgroupModeLegacy {
+		return resolveTargetCgroupPathV1(pid)
+	}
+	cgRel, err := readCgroupV2Path(pid)
+	if err != nil {
+		if mode == CgroupModeHybrid {
+			return resolveTargetCgroupPathV1(pid)
+		}
+		return "", fmt.Errorf("failed to read cgroup for pid %d: %w", pid, err)
+	}
+	cgMount, err := findCgroup2Mount()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cgroup2 mount: %w", err)
+	}
+	return filepath.Join(cgMount, cgRel), nil
+}
+
+// ContainerIDSelector resolves a target by container ID, querying the
+// containerd or docker control socket to translate the ID into its init
+// PID and from there into a cgroup path.
+//
+// At least one of ContainerdSocket or DockerSocket must be reachable; if
+// both are set, containerd is tried first.
+type ContainerIDSelector struct {
+	ContainerID string
+
+	// ContainerdSocket is the containerd gRPC socket, typically
+	// /run/containerd/containerd.sock.
+	ContainerdSocket string
+	// ContainerdNamespace is the containerd namespace the container was
+	// created in (k8s.io for Kubernetes-managed containers).
+	ContainerdNamespace string
+	// DockerSocket is the docker daemon socket, typically
+	// /var/run/docker.sock.
+	DockerSocket string
+
+	// DialTimeout bounds how long socket dials are allowed to take.
+	// Defaults to 5s when zero.
+	DialTimeout time.Duration
+}
+
+// ResolveCgroupPath implements TargetSelector.
+func (s ContainerIDSelector) ResolveCgroupPath(ctx context.Context) (string, error) {
+	if s.ContainerdSocket != "" {
+		pid, err := containerdInitPID(ctx, s.ContainerdSocket, s.ContainerdNamespace, s.ContainerID, s.dialTimeout())
+		if err == nil {
+			return cgroupPathForPID(pid)
+		}
+		if s.DockerSocket == "" {
+			return "", fmt.Errorf("containerd lookup failed for container %q: %w", s.ContainerID, err)
+		}
+	}
+	if s.DockerSocket != "" {
+		pid, err := dockerInitPID(ctx, s.DockerSocket, s.ContainerID, s.dialTimeout())
+		if err != nil {
+			return "", fmt.Errorf("docker lookup failed for container %q: %w", s.ContainerID, err)
+		}
+		return cgroupPathForPID(pid)
+	}
+	return "", fmt.Errorf("no container runtime socket configured for container %q", s.ContainerID)
+}
+
+func (s ContainerIDSelector) dialTimeout() time.Duration {
+	if s.DialTimeout > 0 {
+		return s.DialTimeout
+	}
+	return 5 * time.Second
+}
+
+// KubePodSelector resolves a target by Kubernetes namespace/pod[/container],
+// using the kubelet's CRI socket to look up the pod sandbox (and optionally
+// a specific container within it), then the sandbox or container's cgroup
+// path.
+type KubePodSelector struct {
+	Namespace string
+	Pod       string
+	// Container is optional; if empty the pod sandbox itself is targeted.
+	Container string
+
+	// CRISocket is the CRI runtime socket, typically
+	// /run/containerd/containerd.sock or /var/run/crio/crio.sock.
+	CRISocket string
+
+	DialTimeout time.Duration
+}
+
+// ResolveCgroupPath implements TargetSelector.
+func (s KubePodSelector) ResolveCgroupPath(ctx context.Context) (string, error) {
+	if s.CRISocket == "" {
+		return "", fmt.Errorf("no CRI socket configured for pod %s/%s", s.Namespace, s.Pod)
+	}
+	timeout := s.DialTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	pid, err := criContainerInitPID(ctx, s.CRISocket, s.Namespace, s.Pod, s.Container, timeout)
+	if err != nil {
+		return "", fmt.Errorf("CRI lookup failed for pod %s/%s container %q: %w", s.Namespace, s.Pod, s.Container, err)
+	}
+	return cgroupPathForPID(pid)
+}
+
+// resolveTargetCgroupPaths resolves every selector to an absolute cgroup
+// path. Unlike resolveTargetCgroupPath, which supports a single comm-based
+// or explicit-path target, this is the entry point for the multi-target
+// Loader API: Loader.AddSelectors calls it and registers each resolved
+// path (and its descendants) as its own entry in the ConfigMap hash.
+func resolveTargetCgroupPaths(ctx context.Context, selectors []TargetSelector) ([]string, error) {
+	paths := make([]string, 0, len(selectors))
+	for _, sel := range selectors {
+		p, err := sel.ResolveCgroupPath(ctx)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
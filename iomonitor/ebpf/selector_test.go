@@ -0,0 +1,137 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fakeSelector struct {
+	path string
+	err  error
+}
+
+func (s fakeSelector) ResolveCgroupPath(ctx context.Context) (string, error) {
+	return s.path, s.err
+}
+
+func TestResolveTargetCgroupPaths(t *testing.T) {
+	selectors := []TargetSelector{
+		fakeSelector{path: "/a"},
+		fakeSelector{path: "/b"},
+	}
+	paths, err := resolveTargetCgroupPaths(context.Background(), selectors)
+	if err != nil {
+		t.Fatalf("resolveTargetCgroupPaths: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != "/a" || paths[1] != "/b" {
+		t.Fatalf("paths = %v, want [/a /b]", paths)
+	}
+}
+
+func TestResolveTargetCgroupPaths_StopsOnFirstError(t *testing.T) {
+	wantErr := errors.New("boom")
+	selectors := []TargetSelector{
+		fakeSelector{path: "/a"},
+		fakeSelector{err: wantErr},
+		fakeSelector{path: "/c"},
+	}
+	_, err := resolveTargetCgroupPaths(context.Background(), selectors)
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("err = %v, want wrapping %v", err, wantErr)
+	}
+}
+
+func TestCommSelector_ResolveCgroupPath(t *testing.T) {
+	data, err := os.ReadFile("/proc/self/comm")
+	if err != nil {
+		t.Fatalf("read own comm: %v", err)
+	}
+	sel := CommSelector{Comm: trimComm(string(data))}
+	if sel.Comm == "" {
+		t.Skip("empty comm")
+	}
+	if _, err := sel.ResolveCgroupPath(context.Background()); err != nil {
+		t.Fatalf("ResolveCgroupPath: %v", err)
+	}
+}
+
+func trimComm(s string) string {
+	for len(s) > 0 && (s[len(s)-1] == '\n' || s[len(s)-1] == ' ') {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func TestDockerInitPID(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(dockerInspectResponse{
+			State: struct {
+				Pid int `json:"Pid"`
+			}{Pid: 4242},
+		})
+	}))
+
+	sockDir := t.TempDir()
+	sockPath := filepath.Join(sockDir, "docker.sock")
+	l, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatalf("listen unix socket: %v", err)
+	}
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	pid, err := dockerInitPID(context.Background(), sockPath, "abc123", time.Second)
+	if err != nil {
+		t.Fatalf("dockerInitPID: %v", err)
+	}
+	if pid != 4242 {
+		t.Fatalf("pid = %d, want 4242", pid)
+	}
+}
+
+func TestDockerInitPID_NoPid(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(dockerInspectResponse{})
+	}))
+
+	sockDir := t.TempDir()
+	sockPath := filepath.Join(sockDir, "docker.sock")
+	l, err := net.Listen("unix", sockPath)
+	if err != nil {
+		t.Fatalf("listen unix socket: %v", err)
+	}
+	srv.Listener = l
+	srv.Start()
+	defer srv.Close()
+
+	if _, err := dockerInitPID(context.Background(), sockPath, "abc123", time.Second); err == nil {
+		t.Fatal("expected error for missing pid")
+	}
+}
+
+func TestParseVerbosePID(t *testing.T) {
+	pid, err := parseVerbosePID(map[string]string{"info": `{"pid":777}`})
+	if err != nil {
+		t.Fatalf("parseVerbosePID: %v", err)
+	}
+	if pid != 777 {
+		t.Fatalf("pid = %d, want 777", pid)
+	}
+}
+
+func TestParseVerbosePID_Missing(t *testing.T) {
+	if _, err := parseVerbosePID(map[string]string{}); err == nil {
+		t.Fatal("expected error for missing info entry")
+	}
+}
@@ -0,0 +1,166 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// SystemdUnitSelector resolves a target by systemd unit name, e.g.
+// "banyand.service", "system.slice/banyand.service", or "session-c1.scope".
+// When systemd is reachable over D-Bus, the unit's actual ControlGroup
+// property is used; otherwise the path is constructed from the unit name
+// using the standard cgroup v2 + systemd driver layout.
+type SystemdUnitSelector struct {
+	Unit string
+
+	// DBusTimeout bounds how long the D-Bus call is allowed to take before
+	// falling back to path construction. Defaults to 2s when zero.
+	DBusTimeout time.Duration
+}
+
+// ResolveCgroupPath implements TargetSelector.
+func (s SystemdUnitSelector) ResolveCgroupPath(ctx context.Context) (string, error) {
+	cgMount, err := findCgroup2Mount()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cgroup2 mount: %w", err)
+	}
+
+	timeout := s.DBusTimeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	if rel, err := systemdUnitControlGroup(ctx, s.Unit, timeout); err == nil {
+		return filepath.Join(cgMount, rel), nil
+	}
+
+	rel, err := systemdUnitCgroupPath(s.Unit)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve cgroup path for unit %q: %w", s.Unit, err)
+	}
+	return filepath.Join(cgMount, rel), nil
+}
+
+// systemdUnitControlGroup asks the running systemd instance (over the
+// system D-Bus) for the ControlGroup property of unit, which is the
+// authoritative cgroup v2 path for that unit.
+func systemdUnitControlGroup(ctx context.Context, unit string, timeout time.Duration) (string, error) {
+	conn, err := dbus.SystemBus()
+	if err != nil {
+		return "", fmt.Errorf("connect to system bus: %w", err)
+	}
+	defer conn.Close()
+
+	dialCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	manager := conn.Object("org.freedesktop.systemd1", dbus.ObjectPath("/org/freedesktop/systemd1"))
+
+	var unitPath dbus.ObjectPath
+	if err := manager.CallWithContext(dialCtx, "org.freedesktop.systemd1.Manager.GetUnit", 0, unit).Store(&unitPath); err != nil {
+		return "", fmt.Errorf("GetUnit(%s): %w", unit, err)
+	}
+
+	unitObj := conn.Object("org.freedesktop.systemd1", unitPath)
+	variant, err := unitObj.GetProperty("org.freedesktop.systemd1.Unit.ControlGroup")
+	if err != nil {
+		return "", fmt.Errorf("get ControlGroup property for %s: %w", unit, err)
+	}
+	cgPath, ok := variant.Value().(string)
+	if !ok || cgPath == "" {
+		return "", fmt.Errorf("unit %s has no control group", unit)
+	}
+	return cgPath, nil
+}
+
+// systemdUnitCgroupPath constructs the expected cgroup v2 relative path for
+// a systemd unit without talking to systemd, using the standard systemd
+// cgroup driver layout:
+//
+//   - A path containing "/" (e.g. "system.slice/banyand.service") is assumed
+//     to already be relative to the cgroup2 mount and is used as-is.
+//   - A ".slice" unit nests under its parent slices: "-" in the name
+//     delimits hierarchy levels, so "foo-bar.slice" lives at
+//     "/foo.slice/foo-bar.slice".
+//   - Any other unit (.service, .scope, ...) with no slice given is assumed
+//     to live directly under "system.slice", which is where systemd places
+//     unscoped system units; callers that need a unit under a different
+//     slice (e.g. a user session under "user.slice/user-<uid>.slice")
+//     should pass the fully qualified "slice/unit" form instead.
+func systemdUnitCgroupPath(unit string) (string, error) {
+	if unit == "" {
+		return "", fmt.Errorf("empty systemd unit name")
+	}
+	if strings.Contains(unit, "/") {
+		return unit, nil
+	}
+	if strings.HasSuffix(unit, ".slice") {
+		return systemdSlicePath(unit), nil
+	}
+	return filepath.Join(systemdSlicePath("system.slice"), systemdEscape(unit)), nil
+}
+
+// systemdSlicePath expands a slice unit name into its nested cgroup path,
+// e.g. "foo-bar-baz.slice" -> "/foo.slice/foo-bar.slice/foo-bar-baz.slice".
+// slice is run through systemdEscape first so that a raw, not-yet-escaped
+// name (the common case when building one from e.g. a uid or container id)
+// produces the same path a real systemd-escaped unit name would.
+func systemdSlicePath(slice string) string {
+	name := strings.TrimSuffix(systemdEscape(slice), ".slice")
+	if name == "" || name == "-" {
+		return "/"
+	}
+	parts := strings.Split(name, "-")
+	path := ""
+	prefix := ""
+	for _, p := range parts {
+		if prefix == "" {
+			prefix = p
+		} else {
+			prefix = prefix + "-" + p
+		}
+		path = filepath.Join(path, prefix+".slice")
+	}
+	return "/" + path
+}
+
+// systemdEscape implements the subset of systemd-escape's algorithm needed
+// to turn an arbitrary string (e.g. a device or mount path) into a valid
+// systemd unit name component: "/" becomes "-", and every byte outside
+// [A-Za-z0-9:_.-] is replaced by its C-style "\xHH" escape. Unlike
+// systemd-escape, a literal "-" is kept as-is rather than escaped. A leading "."
+// is always escaped so the result can't be mistaken for a hidden file or
+// collide with a unit type suffix.
+func systemdEscape(s string) string {
+	s = strings.ReplaceAll(s, "/", "-")
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case i == 0 && c == '.':
+			fmt.Fprintf(&b, `\x%02x`, c)
+		case isUnitNameChar(c):
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, `\x%02x`, c)
+		}
+	}
+	return b.String()
+}
+
+func isUnitNameChar(c byte) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return true
+	case c == ':', c == '_', c == '.', c == '-':
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,86 @@
+//go:build linux
+
+package ebpf
+
+import "testing"
+
+func TestSystemdSlicePath(t *testing.T) {
+	cases := map[string]string{
+		"-.slice":           "/",
+		"system.slice":      "/system.slice",
+		"foo-bar.slice":     "/foo.slice/foo-bar.slice",
+		"foo-bar-baz.slice": "/foo.slice/foo-bar.slice/foo-bar-baz.slice",
+	}
+	for in, want := range cases {
+		if got := systemdSlicePath(in); got != want {
+			t.Errorf("systemdSlicePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSystemdUnitCgroupPath(t *testing.T) {
+	cases := map[string]string{
+		"system.slice/banyand.service": "system.slice/banyand.service",
+		"banyand.service":              "/system.slice/banyand.service",
+		"foo-bar.slice":                "/foo.slice/foo-bar.slice",
+	}
+	for in, want := range cases {
+		got, err := systemdUnitCgroupPath(in)
+		if err != nil {
+			t.Fatalf("systemdUnitCgroupPath(%q): %v", in, err)
+		}
+		if got != want {
+			t.Errorf("systemdUnitCgroupPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSystemdUnitCgroupPath_Empty(t *testing.T) {
+	if _, err := systemdUnitCgroupPath(""); err == nil {
+		t.Fatal("expected error for empty unit name")
+	}
+}
+
+func TestSystemdUnitCgroupPath_Escapes(t *testing.T) {
+	got, err := systemdUnitCgroupPath("a b.service")
+	if err != nil {
+		t.Fatalf("systemdUnitCgroupPath: %v", err)
+	}
+	want := `/system.slice/a\x20b.service`
+	if got != want {
+		t.Errorf("systemdUnitCgroupPath(%q) = %q, want %q", "a b.service", got, want)
+	}
+}
+
+func TestSystemdSlicePath_Escapes(t *testing.T) {
+	got := systemdSlicePath("foo-a b.slice")
+	want := `/foo.slice/foo-a\x20b.slice`
+	if got != want {
+		t.Errorf("systemdSlicePath(%q) = %q, want %q", "foo-a b.slice", got, want)
+	}
+}
+
+func TestSystemdUnitCgroupPath_EscapesSliceOnlyOnce(t *testing.T) {
+	got, err := systemdUnitCgroupPath("foo-a b.slice")
+	if err != nil {
+		t.Fatalf("systemdUnitCgroupPath: %v", err)
+	}
+	want := `/foo.slice/foo-a\x20b.slice`
+	if got != want {
+		t.Errorf("systemdUnitCgroupPath(%q) = %q, want %q (got escaped twice?)", "foo-a b.slice", got, want)
+	}
+}
+
+func TestSystemdEscape(t *testing.T) {
+	cases := map[string]string{
+		"/dev/sda1": `-dev-sda1`,
+		"foo":       "foo",
+		".hidden":   `\x2ehidden`,
+		"a b":       `a\x20b`,
+	}
+	for in, want := range cases {
+		if got := systemdEscape(in); got != want {
+			t.Errorf("systemdEscape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"time"
+)
+
+// TargetState describes a transition in a watched target's lifecycle.
+type TargetState int
+
+const (
+	// TargetFound means selector now resolves to CgroupPath/CgroupID.
+	TargetFound TargetState = iota
+	// TargetLost means the previously resolved target is gone (the
+	// process exited, or the selector no longer matches anything).
+	TargetLost
+)
+
+func (s TargetState) String() string {
+	switch s {
+	case TargetFound:
+		return "found"
+	case TargetLost:
+		return "lost"
+	default:
+		return "unknown"
+	}
+}
+
+// TargetEvent reports a target lifecycle transition observed by Watcher.
+// PID is 0 when the selector in use can't cheaply report one (e.g. a
+// systemd unit or container selector).
+type TargetEvent struct {
+	PID        int
+	CgroupPath string
+	CgroupID   uint64
+	State      TargetState
+}
+
+// TargetSink is the subset of Loader's multi-target API Watcher needs to
+// atomically swap filtered cgroups across a target's lifecycle. *Loader
+// satisfies this interface.
+type TargetSink interface {
+	AddTarget(path string) (uint64, error)
+	RemoveTarget(id uint64) error
+}
+
+// Watcher re-resolves a TargetSelector as the process it matches restarts
+// (new PID, possibly a new cgroup) or disappears, keeping a TargetSink's
+// filtered cgroup set in sync so no events are lost across the transition.
+type Watcher struct {
+	// PollInterval bounds how long a transition can go unnoticed when the
+	// kernel process connector isn't available (e.g. no CAP_NET_ADMIN).
+	// Defaults to 500ms when zero. Even when the connector is available it
+	// is used as a safety net against missed or coalesced notifications.
+	PollInterval time.Duration
+
+	// Logf, if set, receives diagnostic messages that don't otherwise
+	// surface through the TargetEvent channel, such as a failed
+	// sink.AddTarget call during a swap. Defaults to a no-op.
+	Logf func(format string, args ...interface{})
+
+	// LostThreshold is the number of consecutive failed resolutions
+	// required before a target is declared lost. This debounces transient
+	// resolve failures (a momentary /proc read race, a container-runtime
+	// API hiccup) so they don't get reported, and filtered, as a restart.
+	// Defaults to 3 when zero.
+	LostThreshold int
+}
+
+// NewWatcher returns a Watcher with default settings.
+func NewWatcher() *Watcher {
+	return &Watcher{}
+}
+
+func (w *Watcher) pollInterval() time.Duration {
+	if w.PollInterval > 0 {
+		return w.PollInterval
+	}
+	return 500 * time.Millisecond
+}
+
+func (w *Watcher) logf(format string, args ...interface{}) {
+	if w.Logf != nil {
+		w.Logf(format, args...)
+	}
+}
+
+func (w *Watcher) lostThreshold() int {
+	if w.LostThreshold > 0 {
+		return w.LostThreshold
+	}
+	return 3
+}
+
+// WatchTarget resolves selector against sink whenever a process lifecycle
+// transition is observed (or, absent that signal, on every poll tick) and
+// atomically swaps sink's target set to match: the new cgroup id is added
+// before the stale one is removed, so there is no window where filtering
+// covers neither, and a TargetEvent is emitted for each half of the swap.
+// The returned channel is closed once ctx is canceled.
+func (w *Watcher) WatchTarget(ctx context.Context, selector TargetSelector, sink TargetSink) <-chan TargetEvent {
+	events := make(chan TargetEvent)
+
+	wake := make(chan struct{}, 1)
+	if procWatcher, err := newProcEventWatcher(); err == nil {
+		go func() {
+			defer procWatcher.Close()
+			_ = procWatcher.run(ctx, func(ProcEvent) {
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+			})
+		}()
+	}
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(w.pollInterval())
+		defer ticker.Stop()
+
+		var current *TargetEvent
+		var misses int
+		for {
+			pid, path, id, found := resolveSelectorTarget(ctx, selector)
+
+			switch {
+			case found:
+				misses = 0
+				if current == nil || current.CgroupID != id {
+					newID, err := sink.AddTarget(path)
+					if err != nil {
+						w.logf("watcher: add target %s failed, will retry: %v", path, err)
+						break
+					}
+					next := TargetEvent{PID: pid, CgroupPath: path, CgroupID: newID, State: TargetFound}
+					if !emit(ctx, events, next) {
+						return
+					}
+					if current != nil {
+						if err := sink.RemoveTarget(current.CgroupID); err != nil {
+							w.logf("watcher: remove stale target %d failed: %v", current.CgroupID, err)
+						}
+						if !emit(ctx, events, withState(*current, TargetLost)) {
+							return
+						}
+					}
+					current = &next
+				}
+			case current != nil:
+				misses++
+				if misses < w.lostThreshold() {
+					break
+				}
+				if err := sink.RemoveTarget(current.CgroupID); err != nil {
+					w.logf("watcher: remove lost target %d failed: %v", current.CgroupID, err)
+				}
+				if !emit(ctx, events, withState(*current, TargetLost)) {
+					return
+				}
+				current = nil
+				misses = 0
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-wake:
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return events
+}
+
+func withState(ev TargetEvent, state TargetState) TargetEvent {
+	ev.State = state
+	return ev
+}
+
+func emit(ctx context.Context, events chan<- TargetEvent, ev TargetEvent) bool {
+	select {
+	case events <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// resolveSelectorTarget resolves selector to its current cgroup path/id,
+// and, for selectors that can cheaply report one, the PID behind it.
+func resolveSelectorTarget(ctx context.Context, selector TargetSelector) (pid int, path string, id uint64, ok bool) {
+	path, err := selector.ResolveCgroupPath(ctx)
+	if err != nil {
+		return 0, "", 0, false
+	}
+	id, err = cgroupID(path)
+	if err != nil {
+		return 0, "", 0, false
+	}
+	if comm, isComm := selector.(CommSelector); isComm {
+		if p, err := findPIDByComm(comm.Comm); err == nil {
+			pid = p
+		}
+	}
+	return pid, path, id, true
+}
@@ -0,0 +1,114 @@
+//go:build ebpf && linux
+
+package ebpf
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/SkyAPM/ktm-ebpf/iomonitor/ebpf/generated"
+)
+
+// spawnRenamedSleep copies /bin/sleep to a temp file named comm and execs
+// it for the given duration. The kernel sets a process's comm (task_comm)
+// from the executable's filename at exec time, so the spawned process's
+// comm becomes exactly comm without needing to prctl(PR_SET_NAME) a child
+// we don't control.
+func spawnRenamedSleep(t *testing.T, comm string, d time.Duration) *exec.Cmd {
+	t.Helper()
+	if len(comm) > 15 {
+		t.Fatalf("comm %q exceeds the 15-byte kernel comm limit", comm)
+	}
+
+	src, err := os.Open("/bin/sleep")
+	if err != nil {
+		t.Skipf("/bin/sleep not available: %v", err)
+	}
+	defer src.Close()
+
+	binPath := filepath.Join(t.TempDir(), comm)
+	dst, err := os.OpenFile(binPath, os.O_CREATE|os.O_WRONLY, 0o755)
+	if err != nil {
+		t.Fatalf("create temp binary: %v", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		t.Fatalf("copy /bin/sleep: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatalf("close temp binary: %v", err)
+	}
+
+	secs := int(d.Seconds())
+	if secs < 1 {
+		secs = 1
+	}
+	cmd := exec.Command(binPath, strconv.Itoa(secs))
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start %s: %v", binPath, err)
+	}
+	// Reap the child as soon as it exits; an unreaped zombie keeps its comm
+	// and cgroup in /proc and would still resolve as a live target.
+	go func() { _ = cmd.Wait() }()
+	return cmd
+}
+
+func TestLoaderWatchTarget_AttachAndDetach(t *testing.T) {
+	requireRoot(t)
+	bumpMemlock(t)
+
+	var objs generated.IomonitorObjects
+	if err := generated.LoadIomonitorObjects(&objs, nil); err != nil {
+		t.Fatalf("load iomonitor objects: %v", err)
+	}
+	defer objs.Close()
+
+	loader := NewLoader(objs)
+
+	const comm = "ktmebpftarget"
+	cmd := spawnRenamedSleep(t, comm, 2*time.Second)
+	defer func() { _ = cmd.Process.Kill() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+
+	watcher := &Watcher{PollInterval: 50 * time.Millisecond}
+	events := watcher.WatchTarget(ctx, CommSelector{Comm: comm}, loader)
+
+	found := waitForState(t, events, TargetFound, 3*time.Second)
+	if found.PID != cmd.Process.Pid {
+		t.Fatalf("found event pid = %d, want %d", found.PID, cmd.Process.Pid)
+	}
+	if targets := loader.ListTargets(); len(targets) == 0 {
+		t.Fatal("expected loader to have a registered target after TargetFound")
+	}
+
+	lost := waitForState(t, events, TargetLost, 4*time.Second)
+	if lost.CgroupID != found.CgroupID {
+		t.Fatalf("lost event cgroup id = %d, want %d", lost.CgroupID, found.CgroupID)
+	}
+}
+
+func waitForState(t *testing.T, events <-chan TargetEvent, want TargetState, timeout time.Duration) TargetEvent {
+	t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatalf("events channel closed before observing %v", want)
+			}
+			if ev.State == want {
+				return ev
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for %v event", want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build linux
+
+package ebpf
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTargetState_String(t *testing.T) {
+	if TargetFound.String() != "found" {
+		t.Fatalf("TargetFound.String() = %q", TargetFound.String())
+	}
+	if TargetLost.String() != "lost" {
+		t.Fatalf("TargetLost.String() = %q", TargetLost.String())
+	}
+}
+
+func TestResolveSelectorTarget_CommSelector(t *testing.T) {
+	pid, path, id, ok := resolveSelectorTarget(context.Background(), CommSelector{Comm: "__nonexistent_process_name__"})
+	if ok {
+		t.Fatalf("expected resolution to fail, got pid=%d path=%q id=%d", pid, path, id)
+	}
+}